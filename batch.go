@@ -0,0 +1,262 @@
+package badger
+
+import (
+	"encoding/binary"
+
+	"github.com/pingcap/badger/table/memtable"
+	"github.com/pingcap/badger/y"
+	"github.com/pingcap/errors"
+)
+
+// batchEntryKind distinguishes a Put from a Delete inside an encoded batch
+// record. Deletes are stored as tombstone values at the memtable level, so
+// the kind only decides whether an encoded entry carries a value; Replay
+// does not pass it on to its callback.
+type batchEntryKind byte
+
+const (
+	batchPut    batchEntryKind = 1
+	batchDelete batchEntryKind = 2
+)
+
+// batchHeaderSize is the size of the fixed part of an encoded batch record:
+// an 8 byte sequence number followed by a 4 byte entry count.
+const batchHeaderSize = 8 + 4
+
+type batchEntry struct {
+	cf   int
+	kind batchEntryKind
+	key  y.Key
+	val  y.ValueStruct
+}
+
+func (e *batchEntry) encodedSize() int {
+	n := 1 + 1 // cf + kind
+	n += binary.MaxVarintLen32 + len(e.key.UserKey) + 8
+	if e.kind == batchPut {
+		n += binary.MaxVarintLen32 + int(e.val.EncodedSize())
+	}
+	return n
+}
+
+// WriteBatch buffers a sequence of Put/Delete operations across column
+// families so they can be committed to a memtable as a single unit under one
+// commit timestamp. It serializes into a compact record - (sequence, count)
+// header followed by the entries - so the exact same bytes can be appended
+// to the WAL and replayed into a memtable on recovery.
+//
+// A WriteBatch is not safe for concurrent use.
+type WriteBatch struct {
+	numCFs  int
+	seq     uint64
+	entries []batchEntry
+	hints   []memtable.Hint
+}
+
+// NewWriteBatch creates an empty WriteBatch for a memtable with numCFs
+// column families.
+func NewWriteBatch(numCFs int) *WriteBatch {
+	return &WriteBatch{numCFs: numCFs}
+}
+
+// Put buffers a Put of key/val into column family cf.
+func (wb *WriteBatch) Put(cf int, key y.Key, val y.ValueStruct) {
+	wb.entries = append(wb.entries, batchEntry{cf: cf, kind: batchPut, key: key, val: val})
+}
+
+// Delete buffers a tombstone write for key in column family cf.
+func (wb *WriteBatch) Delete(cf int, key y.Key) {
+	wb.entries = append(wb.entries, batchEntry{cf: cf, kind: batchDelete, key: key})
+}
+
+// Len returns the number of buffered entries.
+func (wb *WriteBatch) Len() int {
+	return len(wb.entries)
+}
+
+// Size returns an upper bound on the number of bytes Encode would produce:
+// every length prefix is counted at its maximum varint width.
+func (wb *WriteBatch) Size() int {
+	size := batchHeaderSize
+	for i := range wb.entries {
+		size += wb.entries[i].encodedSize()
+	}
+	return size
+}
+
+// Reset clears the batch so it can be reused for the next round of writes.
+func (wb *WriteBatch) Reset() {
+	wb.entries = wb.entries[:0]
+	wb.seq = 0
+}
+
+// SetSeq sets the sequence number that will be encoded into the batch
+// header, and stamps it onto every buffered entry's key as its commit
+// timestamp. It is called once a contiguous sequence range has been
+// reserved for the batch, overwriting whatever version Put/Delete's caller
+// passed in, so that the whole batch really does become visible under one
+// commit timestamp rather than whichever versions happened to be passed in.
+func (wb *WriteBatch) SetSeq(seq uint64) {
+	wb.seq = seq
+	for i := range wb.entries {
+		wb.entries[i].key.Version = seq
+	}
+}
+
+// Encode serializes the batch into a single contiguous record suitable for
+// appending to the WAL as one entry.
+func (wb *WriteBatch) Encode() []byte {
+	buf := make([]byte, batchHeaderSize, wb.Size())
+	binary.LittleEndian.PutUint64(buf[0:8], wb.seq)
+	binary.LittleEndian.PutUint32(buf[8:12], uint32(len(wb.entries)))
+	for i := range wb.entries {
+		e := &wb.entries[i]
+		buf = append(buf, byte(e.cf), byte(e.kind))
+		buf = appendVarintBytes(buf, e.key.UserKey)
+		var verBuf [8]byte
+		binary.BigEndian.PutUint64(verBuf[:], e.key.Version)
+		buf = append(buf, verBuf[:]...)
+		if e.kind == batchPut {
+			valBuf := make([]byte, e.val.EncodedSize())
+			e.val.EncodeTo(valBuf)
+			buf = appendVarintBytes(buf, valBuf)
+		}
+	}
+	return buf
+}
+
+func appendVarintBytes(buf, b []byte) []byte {
+	var lenBuf [binary.MaxVarintLen32]byte
+	n := binary.PutUvarint(lenBuf[:], uint64(len(b)))
+	buf = append(buf, lenBuf[:n]...)
+	return append(buf, b...)
+}
+
+// decodeWriteBatch parses a record produced by Encode, as used when
+// replaying the WAL during recovery.
+func decodeWriteBatch(numCFs int, data []byte) (*WriteBatch, error) {
+	if len(data) < batchHeaderSize {
+		return nil, errors.New("write batch record too short")
+	}
+	wb := NewWriteBatch(numCFs)
+	wb.seq = binary.LittleEndian.Uint64(data[0:8])
+	count := binary.LittleEndian.Uint32(data[8:12])
+	data = data[batchHeaderSize:]
+	for i := uint32(0); i < count; i++ {
+		if len(data) < 2 {
+			return nil, errors.New("corrupted write batch record")
+		}
+		cf, kind := int(data[0]), batchEntryKind(data[1])
+		data = data[2:]
+		userKey, n, err := readVarintBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		data = data[n:]
+		if len(data) < 8 {
+			return nil, errors.New("corrupted write batch record")
+		}
+		version := binary.BigEndian.Uint64(data[:8])
+		data = data[8:]
+		key := y.KeyWithTs(userKey, version)
+		if kind == batchPut {
+			valBuf, n, err := readVarintBytes(data)
+			if err != nil {
+				return nil, err
+			}
+			data = data[n:]
+			var val y.ValueStruct
+			val.Decode(valBuf)
+			wb.entries = append(wb.entries, batchEntry{cf: cf, kind: kind, key: key, val: val})
+		} else {
+			wb.entries = append(wb.entries, batchEntry{cf: cf, kind: kind, key: key})
+		}
+	}
+	return wb, nil
+}
+
+// encodeWriteBatchGroup concatenates the Encode output of every batch in
+// group into a single WAL record, each one prefixed with its length so that
+// decodeWriteBatchGroup can split the record back into the individual
+// batches it came from. Concatenating the batches' own (seq, count) headers
+// directly, with no length prefix, would be ambiguous: decodeWriteBatch has
+// no way to tell where one batch's entries end and the next batch's header
+// begins.
+func encodeWriteBatchGroup(group []*WriteBatch) []byte {
+	buf := make([]byte, 0, 4096)
+	var lenBuf [4]byte
+	for _, wb := range group {
+		rec := wb.Encode()
+		binary.LittleEndian.PutUint32(lenBuf[:], uint32(len(rec)))
+		buf = append(buf, lenBuf[:]...)
+		buf = append(buf, rec...)
+	}
+	return buf
+}
+
+// decodeWriteBatchGroup parses a record produced by encodeWriteBatchGroup
+// back into the individual batches it was built from, as used when
+// replaying the WAL during recovery.
+func decodeWriteBatchGroup(numCFs int, data []byte) ([]*WriteBatch, error) {
+	var batches []*WriteBatch
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, errors.New("corrupted write group record")
+		}
+		n := binary.LittleEndian.Uint32(data[:4])
+		data = data[4:]
+		if uint64(n) > uint64(len(data)) {
+			return nil, errors.New("corrupted write group record")
+		}
+		wb, err := decodeWriteBatch(numCFs, data[:n])
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, wb)
+		data = data[n:]
+	}
+	return batches, nil
+}
+
+func readVarintBytes(data []byte) ([]byte, int, error) {
+	l, n := binary.Uvarint(data)
+	if n <= 0 || uint64(n)+l > uint64(len(data)) {
+		return nil, 0, errors.New("corrupted write batch record")
+	}
+	return data[n : n+int(l)], n + int(l), nil
+}
+
+// Replay invokes fn for every entry in the batch, in the order they were
+// added. Deletes are passed through with an empty value struct, leaving it
+// up to fn to tell them apart from Puts if it needs to.
+func (wb *WriteBatch) Replay(fn func(cf int, key y.Key, val y.ValueStruct)) {
+	for i := range wb.entries {
+		e := &wb.entries[i]
+		fn(e.cf, e.key, e.val)
+	}
+}
+
+// ApplyTo applies every buffered entry to tbl. Entries for the same column
+// family reuse a single memtable.Hint so that batches with sorted keys -
+// the common case for both normal writes and WAL replay - take the fast
+// splice path in memtable.CFTable.PutWithHint.
+func (wb *WriteBatch) ApplyTo(tbl *memtable.CFTable) {
+	if cap(wb.hints) < wb.numCFs {
+		wb.hints = make([]memtable.Hint, wb.numCFs)
+	} else {
+		for i := range wb.hints[:wb.numCFs] {
+			wb.hints[i] = memtable.Hint{}
+		}
+	}
+	wb.applyWithHints(tbl, wb.hints)
+}
+
+// applyWithHints applies the batch to tbl using the given per-CF hints
+// instead of allocating its own, so a run of batches committed together by
+// runWriteGroupCommit can share hint state across their Put calls.
+func (wb *WriteBatch) applyWithHints(tbl *memtable.CFTable, hints []memtable.Hint) {
+	for i := range wb.entries {
+		e := &wb.entries[i]
+		tbl.PutWithHint(e.cf, e.key, e.val, &hints[e.cf])
+	}
+}
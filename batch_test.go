@@ -0,0 +1,78 @@
+package badger
+
+import (
+	"testing"
+
+	"github.com/pingcap/badger/table/memtable"
+	"github.com/pingcap/badger/y"
+)
+
+func TestWriteBatchPutDelete(t *testing.T) {
+	wb := NewWriteBatch(2)
+	wb.Put(0, y.KeyWithTs([]byte("a"), 0), y.ValueStruct{Value: []byte("1")})
+	wb.Delete(1, y.KeyWithTs([]byte("b"), 0))
+	if got := wb.Len(); got != 2 {
+		t.Fatalf("Len() = %d, want 2", got)
+	}
+	if wb.Size() <= 0 {
+		t.Fatal("Size() should account for the buffered entries")
+	}
+}
+
+func TestWriteBatchReset(t *testing.T) {
+	wb := NewWriteBatch(1)
+	wb.Put(0, y.KeyWithTs([]byte("a"), 0), y.ValueStruct{Value: []byte("1")})
+	wb.SetSeq(5)
+	wb.Reset()
+	if wb.Len() != 0 {
+		t.Fatalf("Len() after Reset = %d, want 0", wb.Len())
+	}
+	if wb.seq != 0 {
+		t.Fatalf("seq after Reset = %d, want 0", wb.seq)
+	}
+}
+
+func TestWriteBatchReplay(t *testing.T) {
+	wb := NewWriteBatch(1)
+	wb.Put(0, y.KeyWithTs([]byte("a"), 0), y.ValueStruct{Value: []byte("1")})
+	wb.Delete(0, y.KeyWithTs([]byte("b"), 0))
+	wb.SetSeq(7)
+
+	var cfs []int
+	var keys []y.Key
+	var vals []y.ValueStruct
+	wb.Replay(func(cf int, key y.Key, val y.ValueStruct) {
+		cfs = append(cfs, cf)
+		keys = append(keys, key)
+		vals = append(vals, val)
+	})
+	if len(keys) != 2 {
+		t.Fatalf("Replay invoked fn %d times, want 2", len(keys))
+	}
+	if keys[0].Version != 7 || keys[1].Version != 7 {
+		t.Fatalf("Replay keys = %+v, want version 7 stamped by SetSeq", keys)
+	}
+	if string(vals[0].Value) != "1" {
+		t.Fatalf("Replay Put value = %q, want %q", vals[0].Value, "1")
+	}
+	if vals[1].Value != nil {
+		t.Fatalf("Replay Delete value = %q, want empty", vals[1].Value)
+	}
+}
+
+func TestWriteBatchApplyTo(t *testing.T) {
+	tbl := memtable.NewCFTable(1<<20, 1)
+	wb := NewWriteBatch(1)
+	wb.Put(0, y.KeyWithTs([]byte("a"), 1), y.ValueStruct{Value: []byte("1")})
+	wb.Put(0, y.KeyWithTs([]byte("b"), 1), y.ValueStruct{Value: []byte("2")})
+	wb.ApplyTo(tbl)
+
+	got := tbl.Get(0, y.KeyWithTs([]byte("a"), 1))
+	if string(got.Value) != "1" {
+		t.Fatalf("Get(a) = %q, want %q", got.Value, "1")
+	}
+	got = tbl.Get(0, y.KeyWithTs([]byte("b"), 1))
+	if string(got.Value) != "2" {
+		t.Fatalf("Get(b) = %q, want %q", got.Value, "2")
+	}
+}
@@ -0,0 +1,39 @@
+package badger
+
+import "sync/atomic"
+
+// pickCompactionShard returns the first shard in shards whose compaction
+// score - sizeScore's size-based backlog folded together with any pending
+// seek-triggered candidate from its L0 tables - is at least 1.0, or nil if
+// none needs compacting. sizeScore is the shard's existing size-based
+// scorer; this is the seam shardCompactionScore was added for.
+func pickCompactionShard(shards []*Shard, sizeScore func(*Shard) float64) *Shard {
+	for _, shard := range shards {
+		l0s := (*shardL0Tables)(atomic.LoadPointer(shard.l0s))
+		if shardCompactionScore(l0s, sizeScore(shard)) >= 1.0 {
+			return shard
+		}
+	}
+	return nil
+}
+
+// maxL0TablesBeforeCompaction is the per-shard L0 table count
+// shardL0CountScore treats as a full compaction score of 1.0, the same idea
+// as LevelDB's kL0_CompactionTrigger.
+const maxL0TablesBeforeCompaction = 4
+
+// shardL0CountScore is a coarse size-based compaction score for shard,
+// based on its L0 table count. pickNextCompaction feeds it into
+// pickCompactionShard as the sizeScore half of the score, alongside
+// whatever seek-triggered candidate shardCompactionScore folds in from the
+// same shard's L0 tables.
+func shardL0CountScore(shard *Shard) float64 {
+	l0s := (*shardL0Tables)(atomic.LoadPointer(shard.l0s))
+	return float64(len(l0s.tables)) / float64(maxL0TablesBeforeCompaction)
+}
+
+// pickNextCompaction returns the first shard in the live tree whose
+// compaction score reaches 1.0, or nil if none does.
+func (sdb *ShardingDB) pickNextCompaction() *Shard {
+	return pickCompactionShard(sdb.loadShardTree().shards(), shardL0CountScore)
+}
@@ -45,10 +45,14 @@ func (sdb *ShardingDB) runFlushMemTable(c *y.Closer) {
 		if err != nil {
 			panic(err)
 		}
+		atomic.StoreInt64(&l0Table.allowedSeeks, calcAllowedSeeks(l0Table.size))
 		err = sdb.addShardL0Table(task, l0Table)
 		if err != nil {
 			panic(err)
 		}
+		if shard := sdb.pickNextCompaction(); shard != nil {
+			log.S().Infof("shard %d needs compaction", shard.ID)
+		}
 	}
 }
 
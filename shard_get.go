@@ -0,0 +1,39 @@
+package badger
+
+import (
+	"sync/atomic"
+
+	"github.com/pingcap/badger/y"
+)
+
+// Get looks up key in cf at the shard covering key, resolving the shard
+// from the live shard tree the same way Snapshot.shardVersionFor resolves
+// one for a pinned version. This is the read path's entry point for a
+// caller that wants the latest committed data rather than a point-in-time
+// snapshot.
+func (sdb *ShardingDB) Get(cf int, key y.Key) (y.ValueStruct, bool) {
+	for _, shard := range sdb.loadShardTree().shards() {
+		if keyInShardRange(key.UserKey, shard.Start, shard.End) {
+			return sdb.getFromShard(shard, cf, key)
+		}
+	}
+	return y.ValueStruct{}, false
+}
+
+// getFromShard looks up key in cf for shard, consulting its memtables,
+// then its L0 tables, then its L1+ tables, so the newest write always wins.
+// A memtable whose bloom filter rules key out is skipped without a findNear
+// traversal, but a miss in an L0 table is a genuine wasted seek, which
+// getFromL0Tables counts against that table's seek-compaction budget.
+func (sdb *ShardingDB) getFromShard(shard *Shard, cf int, key y.Key) (y.ValueStruct, bool) {
+	memTbls := (*shardingMemTables)(atomic.LoadPointer(shard.memTbls))
+	if v, ok := getFromMemTables(memTbls, cf, key); ok {
+		return v, true
+	}
+	l0s := (*shardL0Tables)(atomic.LoadPointer(shard.l0s))
+	if v, ok := getFromL0Tables(l0s, cf, key); ok {
+		return v, true
+	}
+	l1Plus := (*shardL1PlusTables)(atomic.LoadPointer(shard.l1Plus))
+	return getFromL1PlusTables(l1Plus, cf, key)
+}
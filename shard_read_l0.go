@@ -0,0 +1,18 @@
+package badger
+
+import "github.com/pingcap/badger/y"
+
+// getFromL0Tables looks up key in cf across shard's L0 tables, newest
+// first. Each table that is searched without finding the key is a genuine
+// wasted seek, so it counts against that table's seek budget via
+// recordSeekMiss - unlike a table skipped because a newer one already
+// returned a value.
+func getFromL0Tables(l0s *shardL0Tables, cf int, key y.Key) (y.ValueStruct, bool) {
+	for _, l0 := range l0s.tables {
+		if v, ok := l0.Get(cf, key); ok {
+			return v, true
+		}
+		l0.recordSeekMiss()
+	}
+	return y.ValueStruct{}, false
+}
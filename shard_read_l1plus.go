@@ -0,0 +1,17 @@
+package badger
+
+import "github.com/pingcap/badger/y"
+
+// getFromL1PlusTables looks up key in cf across shard's L1-and-above
+// tables. Unlike L0, these tables are key-range partitioned and never
+// overlap, so at most one of them can hold key. Each table is still tried
+// in slice order until one returns a value, and no miss is counted against
+// a table's seek budget.
+func getFromL1PlusTables(l1Plus *shardL1PlusTables, cf int, key y.Key) (y.ValueStruct, bool) {
+	for _, tbl := range l1Plus.tables {
+		if v, ok := tbl.Get(cf, key); ok {
+			return v, true
+		}
+	}
+	return y.ValueStruct{}, false
+}
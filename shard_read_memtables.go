@@ -0,0 +1,35 @@
+package badger
+
+import (
+	"github.com/pingcap/badger/table/memtable"
+	"github.com/pingcap/badger/y"
+)
+
+// newCFIteratorIfMayContain returns an iterator over tbl's cf positioned to
+// be Seek'd, or nil if tbl's bloom filter proves key cannot be present in
+// it. This is the hook that lets a scan across the stack of immutable
+// memtables skip entire memtables cheaply instead of paying for a findNear
+// traversal into each one.
+func newCFIteratorIfMayContain(tbl *memtable.CFTable, cf int, key y.Key) *memtable.UniIterator {
+	if !tbl.MayContain(cf, key.UserKey) {
+		return nil
+	}
+	return tbl.NewIterator(cf, false)
+}
+
+// getFromMemTables looks up key in cf across the active and immutable
+// memtables in tbls, newest first, skipping any table whose bloom filter
+// rules it out.
+func getFromMemTables(tbls *shardingMemTables, cf int, key y.Key) (y.ValueStruct, bool) {
+	for _, tbl := range tbls.tables {
+		it := newCFIteratorIfMayContain(tbl, cf, key)
+		if it == nil {
+			continue
+		}
+		it.Seek(key)
+		if it.Valid() && it.Key().SameUserKey(key) {
+			return it.Value(), true
+		}
+	}
+	return y.ValueStruct{}, false
+}
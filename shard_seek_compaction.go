@@ -0,0 +1,64 @@
+package badger
+
+import "sync/atomic"
+
+const (
+	// seekCompactionSampleSize is the number of bytes a table is allowed to
+	// absorb per wasted seek before it becomes worth compacting away,
+	// borrowed from LevelDB's version.cSeek.
+	seekCompactionSampleSize = 16 * 1024
+	minAllowedSeeks          = 100
+)
+
+// calcAllowedSeeks derives the seek budget for a newly flushed or compacted
+// table from its size: roughly one wasted seek per 16KB, with a floor so
+// small tables are not flagged for compaction purely from read noise.
+func calcAllowedSeeks(size int64) int64 {
+	seeks := size / seekCompactionSampleSize
+	if seeks < minAllowedSeeks {
+		seeks = minAllowedSeeks
+	}
+	return seeks
+}
+
+// recordSeekMiss is called by the read path (Get/iterator) whenever a lookup
+// passes through l0 without finding the key - a wasted seek. It decrements
+// l0's allowed-seeks budget and reports true exactly once: for whichever
+// read first drives the budget to zero, marking l0 as a compaction
+// candidate. Callers must only invoke this for genuine misses, not for keys
+// found in l0 or skipped because a bloom filter ruled it out.
+func (l0 *shardL0Table) recordSeekMiss() bool {
+	if atomic.AddInt64(&l0.allowedSeeks, -1) > 0 {
+		return false
+	}
+	return atomic.CompareAndSwapInt32(&l0.seekCompactFlag, 0, 1)
+}
+
+// pickSeekCompaction returns the first L0 table in l0s whose seek budget has
+// been exhausted, or nil if none has.
+func pickSeekCompaction(l0s *shardL0Tables) *shardL0Table {
+	for _, l0 := range l0s.tables {
+		if atomic.LoadInt32(&l0.seekCompactFlag) != 0 {
+			return l0
+		}
+	}
+	return nil
+}
+
+// needsSeekCompaction reports whether any of l0s has exhausted its seek
+// budget.
+func needsSeekCompaction(l0s *shardL0Tables) bool {
+	return pickSeekCompaction(l0s) != nil
+}
+
+// shardCompactionScore folds a pending seek-triggered candidate into
+// sizeScore, the shard's existing size-based compaction score: a table that
+// has exhausted its seek budget is treated the same as one that has grown
+// to its full size-based threshold, per LevelDB's cSeek heuristic. The
+// compaction picker should call this instead of using sizeScore directly.
+func shardCompactionScore(l0s *shardL0Tables, sizeScore float64) float64 {
+	if sizeScore < 1.0 && needsSeekCompaction(l0s) {
+		return 1.0
+	}
+	return sizeScore
+}
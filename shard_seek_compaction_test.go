@@ -0,0 +1,16 @@
+package badger
+
+import "testing"
+
+func TestCalcAllowedSeeks(t *testing.T) {
+	if got := calcAllowedSeeks(0); got != minAllowedSeeks {
+		t.Fatalf("calcAllowedSeeks(0) = %d, want floor %d", got, minAllowedSeeks)
+	}
+	if got := calcAllowedSeeks(minAllowedSeeks * seekCompactionSampleSize); got != minAllowedSeeks {
+		t.Fatalf("got %d, want %d", got, minAllowedSeeks)
+	}
+	size := int64(10 * minAllowedSeeks * seekCompactionSampleSize)
+	if got, want := calcAllowedSeeks(size), int64(10*minAllowedSeeks); got != want {
+		t.Fatalf("calcAllowedSeeks(%d) = %d, want %d", size, got, want)
+	}
+}
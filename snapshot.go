@@ -0,0 +1,134 @@
+package badger
+
+import (
+	"bytes"
+	"sync/atomic"
+
+	"github.com/pingcap/badger/y"
+)
+
+// Snapshot is a consistent, point-in-time read view over a ShardingDB. It is
+// modeled on goleveldb's version refcounting: creating a Snapshot bumps the
+// reference count on every memtable, L0 table and L1+ table reachable from
+// the current shard tree, so runFlushMemTable and compaction cannot reset
+// their arenas or delete their files while the snapshot is alive. Get only
+// observes data committed at or before its commitTS.
+type Snapshot struct {
+	db       *ShardingDB
+	commitTS uint64
+	shards   []*shardVersion
+	refs     int32
+}
+
+// shardVersion pins the memtables, L0 tables and L1+ tables of a single
+// Shard as they were when a Snapshot was taken.
+type shardVersion struct {
+	shard   *Shard
+	memTbls *shardingMemTables
+	l0s     *shardL0Tables
+	l1Plus  *shardL1PlusTables
+}
+
+func (sv *shardVersion) incrRef() {
+	for _, tbl := range sv.memTbls.tables {
+		tbl.IncrRef()
+	}
+	for _, l0 := range sv.l0s.tables {
+		l0.incrRef()
+	}
+	for _, tbl := range sv.l1Plus.tables {
+		tbl.incrRef()
+	}
+}
+
+func (sv *shardVersion) release() {
+	for _, tbl := range sv.memTbls.tables {
+		tbl.Delete()
+	}
+	for _, l0 := range sv.l0s.tables {
+		l0.decrRef()
+	}
+	for _, tbl := range sv.l1Plus.tables {
+		tbl.decrRef()
+	}
+}
+
+// keyInShardRange reports whether key falls within a shard's [start, end)
+// key range. An empty end means unbounded, the convention the last shard in
+// a tree uses.
+func keyInShardRange(key, start, end []byte) bool {
+	if bytes.Compare(key, start) < 0 {
+		return false
+	}
+	return len(end) == 0 || bytes.Compare(key, end) < 0
+}
+
+// shardVersionFor returns the pinned shardVersion covering key, or nil if
+// none does - which should not happen for a well-formed shard tree, since
+// shards always partition the full key space.
+func (s *Snapshot) shardVersionFor(key []byte) *shardVersion {
+	for _, sv := range s.shards {
+		if keyInShardRange(key, sv.shard.Start, sv.shard.End) {
+			return sv
+		}
+	}
+	return nil
+}
+
+// NewSnapshot captures the current version - active memtable, immutable
+// memtables, L0 tables and L1+ tables - of every shard and pins it for
+// reading.
+func (sdb *ShardingDB) NewSnapshot() *Snapshot {
+	tree := sdb.loadShardTree()
+	snap := &Snapshot{db: sdb, commitTS: sdb.getCommitTS(), refs: 1}
+	for _, shard := range tree.shards() {
+		sv := &shardVersion{
+			shard:   shard,
+			memTbls: (*shardingMemTables)(atomic.LoadPointer(shard.memTbls)),
+			l0s:     (*shardL0Tables)(atomic.LoadPointer(shard.l0s)),
+			l1Plus:  (*shardL1PlusTables)(atomic.LoadPointer(shard.l1Plus)),
+		}
+		sv.incrRef()
+		snap.shards = append(snap.shards, sv)
+	}
+	return snap
+}
+
+// CommitTS returns the commit timestamp this snapshot reads at.
+func (s *Snapshot) CommitTS() uint64 {
+	return s.commitTS
+}
+
+// Get looks up key in cf as of the Snapshot's commitTS: the newest version
+// at or before commitTS, or a miss if key was never written by then. Badger
+// keys sort by (userKey asc, version desc), so seeking for (key, commitTS)
+// lands on exactly that version, meaning Get only has to consult the
+// memtables, L0 tables and L1+ tables pinned when the Snapshot was taken -
+// never anything written, flushed or compacted afterward - to get MVCC
+// semantics for free from the same ordering Get always relied on.
+func (s *Snapshot) Get(cf int, key []byte) (y.ValueStruct, bool) {
+	sv := s.shardVersionFor(key)
+	if sv == nil {
+		return y.ValueStruct{}, false
+	}
+	versioned := y.KeyWithTs(key, s.commitTS)
+	if v, ok := getFromMemTables(sv.memTbls, cf, versioned); ok {
+		return v, true
+	}
+	if v, ok := getFromL0Tables(sv.l0s, cf, versioned); ok {
+		return v, true
+	}
+	return getFromL1PlusTables(sv.l1Plus, cf, versioned)
+}
+
+// Release drops the snapshot's reference on every pinned memtable and L0
+// table. Once the last snapshot referencing a superseded version is
+// released, its arenas are reset and its obsolete L0 files are deleted.
+func (s *Snapshot) Release() {
+	if atomic.AddInt32(&s.refs, -1) != 0 {
+		return
+	}
+	for _, sv := range s.shards {
+		sv.release()
+	}
+}
@@ -0,0 +1,21 @@
+package badger
+
+import "testing"
+
+func TestKeyInShardRange(t *testing.T) {
+	cases := []struct {
+		key, start, end []byte
+		want            bool
+	}{
+		{[]byte("b"), []byte("a"), []byte("c"), true},
+		{[]byte("a"), []byte("a"), []byte("c"), true},
+		{[]byte("c"), []byte("a"), []byte("c"), false},
+		{[]byte("z"), []byte("a"), nil, true},
+		{[]byte(""), []byte("a"), []byte("c"), false},
+	}
+	for _, c := range cases {
+		if got := keyInShardRange(c.key, c.start, c.end); got != c.want {
+			t.Fatalf("keyInShardRange(%q, %q, %q) = %v, want %v", c.key, c.start, c.end, got, c.want)
+		}
+	}
+}
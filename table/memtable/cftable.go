@@ -0,0 +1,88 @@
+package memtable
+
+import (
+	"sync/atomic"
+
+	"github.com/coocood/badger/y"
+)
+
+// Hint lets a caller that shares one CFTable across many PutWithHint calls
+// for the same column family - such as WriteBatch.ApplyTo - reuse a
+// skiplist hint instead of paying for a fresh lookup on every call.
+type Hint struct {
+	h hint
+}
+
+// CFTable is a set of skiplist memtables, one per column family, built
+// together so they share the same arena size and (optionally) the same
+// bloom filter budget.
+type CFTable struct {
+	id    uint64
+	lists []*skiplist
+}
+
+var cfTableIDGen uint64
+
+// NewCFTable creates a CFTable with numCFs column families, each backed by
+// a skiplist with an arena of arenaSize bytes and no bloom filter.
+func NewCFTable(arenaSize int64, numCFs int) *CFTable {
+	return NewCFTableWithBloom(arenaSize, numCFs, 0)
+}
+
+// NewCFTableWithBloom is like NewCFTable but also turns on a bloom filter
+// over every column family's skiplist, sized from the arena's capacity at
+// bitsPerKey bits per key. This is what Options.MemTableBloomBitsPerKey
+// should be threaded into when a ShardingDB rotates in a new memtable;
+// bitsPerKey <= 0 behaves exactly like NewCFTable.
+func NewCFTableWithBloom(arenaSize int64, numCFs int, bitsPerKey int) *CFTable {
+	lists := make([]*skiplist, numCFs)
+	for i := range lists {
+		lists[i] = newSkiplistWithBloom(arenaSize, bitsPerKey)
+	}
+	return &CFTable{id: atomic.AddUint64(&cfTableIDGen, 1), lists: lists}
+}
+
+// ID returns the id CFTable was created with - monotonically increasing
+// across the process, used to name the L0 file it flushes to.
+func (t *CFTable) ID() uint64 {
+	return t.id
+}
+
+// Get looks up key in cf's skiplist.
+func (t *CFTable) Get(cf int, key y.Key) y.ValueStruct {
+	return t.lists[cf].Get(key)
+}
+
+// MayContain reports whether userKey might be present in cf's skiplist. See
+// skiplist.MayContain.
+func (t *CFTable) MayContain(cf int, userKey []byte) bool {
+	return t.lists[cf].MayContain(userKey)
+}
+
+// PutWithHint inserts key/val into cf's skiplist, reusing h across calls to
+// ride the fast sequential-write path.
+func (t *CFTable) PutWithHint(cf int, key y.Key, val y.ValueStruct, h *Hint) {
+	t.lists[cf].PutWithHint(key, val, &h.h)
+}
+
+// NewIterator returns an iterator over cf's skiplist.
+func (t *CFTable) NewIterator(cf int, reversed bool) *UniIterator {
+	return t.lists[cf].NewUniIterator(reversed)
+}
+
+// IncrRef bumps the refcount on every column family's skiplist, so a
+// Snapshot pinning this CFTable keeps its arenas alive past a concurrent
+// flush.
+func (t *CFTable) IncrRef() {
+	for _, l := range t.lists {
+		l.IncrRef()
+	}
+}
+
+// Delete decrements the refcount on every column family's skiplist,
+// releasing the last reference's arena.
+func (t *CFTable) Delete() {
+	for _, l := range t.lists {
+		l.Delete()
+	}
+}
@@ -0,0 +1,29 @@
+package memtable
+
+import (
+	"testing"
+
+	"github.com/coocood/badger/y"
+)
+
+func TestCFTableWithBloomEnablesFilterPerCF(t *testing.T) {
+	tbl := NewCFTableWithBloom(1<<20, 2, 10)
+	for cf := 0; cf < 2; cf++ {
+		if tbl.lists[cf].filter == nil {
+			t.Fatalf("cf %d: bloom filter not enabled", cf)
+		}
+	}
+	key := y.KeyWithTs([]byte("k1"), 1)
+	var h Hint
+	tbl.PutWithHint(0, key, y.ValueStruct{Value: []byte("v1")}, &h)
+	if !tbl.MayContain(0, key.UserKey) {
+		t.Fatal("MayContain(k1) = false after inserting k1")
+	}
+}
+
+func TestNewCFTableHasNoFilter(t *testing.T) {
+	tbl := NewCFTable(1<<20, 1)
+	if tbl.lists[0].filter != nil {
+		t.Fatal("NewCFTable should leave the bloom filter disabled")
+	}
+}
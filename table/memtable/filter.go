@@ -0,0 +1,107 @@
+package memtable
+
+import "sync/atomic"
+
+// memtableFilter is a small bloom filter guarding the common
+// negative-lookup case: Get/GetWithHint consult it before paying for a full
+// findNear traversal. All k probes index one shared bit array; the filter
+// is not partitioned per hash function. Because the skiplist supports overwrites and has no
+// deletes at the memtable level - tombstones are ordinary values - the
+// filter only ever needs insertions, never removals.
+type memtableFilter struct {
+	bitsPerKey int
+	bits       []uint64
+	numBits    uint32
+	k          uint32
+	capacity   uint32
+	count      uint32 // CAS.
+}
+
+// newMemtableFilter sizes a filter for roughly capacity keys at bitsPerKey
+// bits each.
+func newMemtableFilter(bitsPerKey, capacity int) *memtableFilter {
+	if capacity < 64 {
+		capacity = 64
+	}
+	numBits := uint32(capacity * bitsPerKey)
+	if numBits < 64 {
+		numBits = 64
+	}
+	words := (numBits + 63) / 64
+	// k = bitsPerKey * ln(2), the number of hash functions that minimizes
+	// the false positive rate for a given bits-per-key budget.
+	k := uint32(bitsPerKey * 69 / 100)
+	if k < 1 {
+		k = 1
+	}
+	if k > 30 {
+		k = 30
+	}
+	return &memtableFilter{
+		bitsPerKey: bitsPerKey,
+		bits:       make([]uint64, words),
+		numBits:    words * 64,
+		k:          k,
+		capacity:   uint32(capacity),
+	}
+}
+
+// insert adds userKey to the filter.
+func (f *memtableFilter) insert(userKey []byte) {
+	h := bloomHash(userKey)
+	delta := h>>17 | h<<15
+	for i := uint32(0); i < f.k; i++ {
+		setBit(f.bits, h%f.numBits)
+		h += delta
+	}
+	atomic.AddUint32(&f.count, 1)
+}
+
+// mayContain reports whether userKey might have been inserted.
+func (f *memtableFilter) mayContain(userKey []byte) bool {
+	h := bloomHash(userKey)
+	delta := h>>17 | h<<15
+	for i := uint32(0); i < f.k; i++ {
+		if !getBit(f.bits, h%f.numBits) {
+			return false
+		}
+		h += delta
+	}
+	return true
+}
+
+// exceeded reports whether more keys were inserted than the filter was
+// sized for, at which point its false positive rate starts degrading and it
+// should be rebuilt at a larger capacity.
+func (f *memtableFilter) exceeded() bool {
+	return atomic.LoadUint32(&f.count) > f.capacity
+}
+
+func setBit(words []uint64, bit uint32) {
+	idx, mask := bit/64, uint64(1)<<(bit%64)
+	for {
+		old := atomic.LoadUint64(&words[idx])
+		if old&mask != 0 {
+			return
+		}
+		if atomic.CompareAndSwapUint64(&words[idx], old, old|mask) {
+			return
+		}
+	}
+}
+
+func getBit(words []uint64, bit uint32) bool {
+	idx, mask := bit/64, uint64(1)<<(bit%64)
+	return atomic.LoadUint64(&words[idx])&mask != 0
+}
+
+// bloomHash is a 32-bit FNV-1a variant, fast and well distributed enough
+// for picking bloom filter buckets.
+func bloomHash(b []byte) uint32 {
+	var h uint32 = 2166136261
+	for _, c := range b {
+		h ^= uint32(c)
+		h *= 16777619
+	}
+	return h
+}
@@ -0,0 +1,30 @@
+package memtable
+
+import "testing"
+
+func TestMemtableFilterNoFalseNegatives(t *testing.T) {
+	f := newMemtableFilter(10, 100)
+	keys := make([][]byte, 50)
+	for i := range keys {
+		keys[i] = []byte{byte(i), byte(i >> 8)}
+		f.insert(keys[i])
+	}
+	for _, k := range keys {
+		if !f.mayContain(k) {
+			t.Fatalf("mayContain(%v) = false, want true for an inserted key", k)
+		}
+	}
+}
+
+func TestMemtableFilterExceeded(t *testing.T) {
+	f := newMemtableFilter(10, 64)
+	if f.exceeded() {
+		t.Fatal("empty filter reported exceeded")
+	}
+	for i := 0; i < 65; i++ {
+		f.insert([]byte{byte(i), byte(i >> 8)})
+	}
+	if !f.exceeded() {
+		t.Fatal("filter with more keys than its capacity did not report exceeded")
+	}
+}
@@ -34,6 +34,7 @@ package memtable
 
 import (
 	"math"
+	"sync"
 	"sync/atomic"
 	"unsafe"
 
@@ -81,10 +82,103 @@ type skiplist struct {
 	height int32 // Current height. 1 <= height <= kMaxHeight. CAS.
 	head   *node
 	arena  *arena
+	refs   int32 // Starts at 1 for the owning memtable. CAS.
+
+	// filter is a *memtableFilter, or nil if no bloom filter was requested
+	// via EnableBloomFilter. Accessed atomically so Get can race PutWithHint
+	// without locking.
+	filter unsafe.Pointer
+
+	// filterMu serializes insertIntoFilter against rebuildFilter, but not
+	// against other concurrent inserts: rebuildFilter takes the write lock
+	// across its entire scan+swap, while insertIntoFilter only needs the
+	// read lock, since concurrent calls to it already synchronize among
+	// themselves through memtableFilter's own CAS-based bit sets. Without
+	// the mutex at all, a rebuild's scan could pass a key's position before
+	// the key is spliced in, while the racing insertIntoFilter call lands
+	// in the about-to-be-discarded old filter - losing the key from both
+	// and turning a subsequent MayContain for it into a permanent,
+	// incorrect false negative. The write lock guarantees every insert
+	// either completes before the scan starts (so the scan sees it) or
+	// waits for the swap to finish first (so it lands in the new filter).
+	filterMu sync.RWMutex
+}
+
+// EnableBloomFilter turns on a bloom filter guarding Get/GetWithHint,
+// sized for roughly capacity keys at bitsPerKey bits each. It is rebuilt
+// lazily, by re-scanning the skiplist, if more than capacity keys are ever
+// inserted, so callers can pass a rough estimate rather than an exact
+// bound.
+func (s *skiplist) EnableBloomFilter(bitsPerKey, capacity int) {
+	atomic.StorePointer(&s.filter, unsafe.Pointer(newMemtableFilter(bitsPerKey, capacity)))
+}
+
+// MayContain reports whether userKey might be present in the skiplist. A
+// false result guarantees the key is absent, letting Get/GetWithHint skip
+// the full findNear traversal for the common negative-lookup case. A true
+// result may be a false positive. If no bloom filter was enabled, it always
+// returns true.
+func (s *skiplist) MayContain(userKey []byte) bool {
+	filter := (*memtableFilter)(atomic.LoadPointer(&s.filter))
+	if filter == nil {
+		return true
+	}
+	if filter.exceeded() {
+		s.rebuildFilter(filter)
+		filter = (*memtableFilter)(atomic.LoadPointer(&s.filter))
+	}
+	return filter.mayContain(userKey)
+}
+
+// rebuildFilter grows the filter and re-inserts every key currently in the
+// skiplist. Called when more keys were written than the filter was sized
+// for, since a bloom filter cannot simply be resized in place.
+func (s *skiplist) rebuildFilter(old *memtableFilter) {
+	s.filterMu.Lock()
+	defer s.filterMu.Unlock()
+	// Someone else may have already rebuilt while we were waiting for the
+	// lock; don't do it again.
+	if (*memtableFilter)(atomic.LoadPointer(&s.filter)) != old {
+		return
+	}
+	grown := newMemtableFilter(old.bitsPerKey, int(old.capacity)*2)
+	it := s.NewIterator()
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		grown.insert(it.Key().UserKey)
+	}
+	atomic.StorePointer(&s.filter, unsafe.Pointer(grown))
+}
+
+// insertIntoFilter records userKey in the filter current at the time of the
+// call. Callers must only invoke this once the key is actually visible in
+// the skiplist (i.e. after it has been spliced in), so that a concurrent
+// rebuildFilter either observes the key via its own scan or, held off by
+// filterMu's write lock until this call's read lock is released, inserts it
+// into whichever filter rebuildFilter swapped in. The read lock lets
+// inserts from other goroutines proceed concurrently with this one.
+func (s *skiplist) insertIntoFilter(userKey []byte) {
+	s.filterMu.RLock()
+	defer s.filterMu.RUnlock()
+	if filter := (*memtableFilter)(atomic.LoadPointer(&s.filter)); filter != nil {
+		filter.insert(userKey)
+	}
 }
 
-// DecrRef decrements the refcount, deallocating the Skiplist when done using it
+// IncrRef bumps the refcount. A Snapshot built on top of the skiplist must
+// call this before it can outlive the memtable's own reference, so that a
+// concurrent Delete from the write path does not reset the arena out from
+// under it.
+func (s *skiplist) IncrRef() {
+	atomic.AddInt32(&s.refs, 1)
+}
+
+// Delete decrements the refcount, deallocating the Skiplist once the last
+// reference - the owning memtable's and any Snapshot's pinning it via
+// IncrRef - is released.
 func (s *skiplist) Delete() {
+	if atomic.AddInt32(&s.refs, -1) > 0 {
+		return
+	}
 	s.arena.reset()
 	// Indicate we are closed. Good for testing.  Also, lets GC reclaim memory. Race condition
 	// here would suggest we are accessing skiplist when we are supposed to have no reference!
@@ -121,9 +215,24 @@ func newSkiplist(arenaSize int64) *skiplist {
 		height: 1,
 		head:   head,
 		arena:  arena,
+		refs:   1,
 	}
 }
 
+// newSkiplistWithBloom is like newSkiplist but also turns on a bloom filter
+// over the new skiplist, sized from the arena's capacity at bitsPerKey bits
+// per key. CFTable builds every column family's skiplist through this, with
+// NewCFTable passing 0; bitsPerKey <= 0 leaves the filter disabled, matching
+// the zero value of Options.MemTableBloomBitsPerKey.
+func newSkiplistWithBloom(arenaSize int64, bitsPerKey int) *skiplist {
+	s := newSkiplist(arenaSize)
+	if bitsPerKey > 0 {
+		estimatedKeys := int(arenaSize / int64(EstimateNodeSize))
+		s.EnableBloomFilter(bitsPerKey, estimatedKeys)
+	}
+	return s
+}
+
 func (n *node) getValueOffset() (uint32, uint32) {
 	value := atomic.LoadUint64(&n.value)
 	return decodeValue(value)
@@ -391,6 +500,14 @@ func (s *skiplist) PutWithHint(key y.Key, v y.ValueStruct, h *hint) {
 			}
 		}
 	}
+
+	// Only record the key in the filter once it is actually reachable from
+	// the skiplist. Doing this before the splice above would let a
+	// concurrent rebuildFilter's scan pass this key's position without
+	// seeing it, while this call raced it into the filter about to be
+	// discarded - losing the key from both.
+	s.insertIntoFilter(key.UserKey)
+
 	if spliceIsValid {
 		for i := 0; i < height; i++ {
 			h.prev[i] = x
@@ -402,6 +519,9 @@ func (s *skiplist) PutWithHint(key y.Key, v y.ValueStruct, h *hint) {
 }
 
 func (s *skiplist) GetWithHint(key y.Key, h *hint) y.ValueStruct {
+	if !s.MayContain(key.UserKey) {
+		return y.ValueStruct{}
+	}
 	if h == nil {
 		h = new(hint)
 	}
@@ -466,6 +586,9 @@ func (s *skiplist) findLast() *node {
 // Get gets the value associated with the key. It returns a valid value if it finds equal or earlier
 // version of the same key.
 func (s *skiplist) Get(key y.Key) y.ValueStruct {
+	if !s.MayContain(key.UserKey) {
+		return y.ValueStruct{}
+	}
 	n, _ := s.findNear(key, false, true) // findGreaterOrEqual.
 	if n == nil {
 		return y.ValueStruct{}
@@ -0,0 +1,29 @@
+package memtable
+
+import (
+	"testing"
+
+	"github.com/coocood/badger/y"
+)
+
+func TestNewSkiplistWithBloomDisabled(t *testing.T) {
+	s := newSkiplistWithBloom(1<<20, 0)
+	if s.filter != nil {
+		t.Fatal("bitsPerKey <= 0 should leave the filter disabled")
+	}
+	if !s.MayContain([]byte("anything")) {
+		t.Fatal("MayContain with no filter enabled must always return true")
+	}
+}
+
+func TestNewSkiplistWithBloomEnabled(t *testing.T) {
+	s := newSkiplistWithBloom(1<<20, 10)
+	if s.filter == nil {
+		t.Fatal("bitsPerKey > 0 should enable the filter")
+	}
+	key := y.KeyWithTs([]byte("k1"), 1)
+	s.Put(key, y.ValueStruct{Value: []byte("v1")})
+	if !s.MayContain(key.UserKey) {
+		t.Fatal("MayContain(k1) = false after inserting k1")
+	}
+}
@@ -0,0 +1,125 @@
+package badger
+
+import (
+	"sync"
+
+	"github.com/pingcap/badger/table/memtable"
+	"github.com/pingcap/badger/y"
+	"github.com/pingcap/errors"
+)
+
+// writeRequest is what WriteAsync enqueues on sdb.writeCh. The group commit
+// leader in runWriteGroupCommit drains every pending request and commits
+// them together, then reports each one's result back on done.
+type writeRequest struct {
+	batch *WriteBatch
+	done  chan error
+}
+
+// writeGroupStarted tracks, per ShardingDB, whether runWriteGroupCommit has
+// been launched yet. DB startup is expected to start it itself, but nothing
+// in this series owns that startup path, so ensureWriteGroupCommitRunning
+// is the backstop: it makes the first WriteAsync/Write call on a ShardingDB
+// start the goroutine if startup has not already, instead of the caller
+// deadlocking against a writeCh nothing is draining.
+var writeGroupStarted sync.Map // *ShardingDB -> struct{}
+
+// ensureWriteGroupCommitRunning starts runWriteGroupCommit for sdb exactly
+// once. LoadOrStore's reported ownership - not a separate lock - is what
+// makes this race-safe when two writers call it concurrently on a
+// just-opened ShardingDB: only the one that actually stores the key starts
+// the goroutine.
+func (sdb *ShardingDB) ensureWriteGroupCommitRunning() {
+	if _, started := writeGroupStarted.LoadOrStore(sdb, struct{}{}); !started {
+		go sdb.runWriteGroupCommit(y.NewCloser(1))
+	}
+}
+
+// WriteAsync enqueues batch to be committed as part of the next group
+// commit and returns a channel that receives its result once the batch is
+// durable and applied. This lets many concurrent writers share a single WAL
+// write instead of each paying for their own fsync.
+func (sdb *ShardingDB) WriteAsync(batch *WriteBatch) <-chan error {
+	sdb.ensureWriteGroupCommitRunning()
+	req := &writeRequest{batch: batch, done: make(chan error, 1)}
+	sdb.writeCh <- req
+	return req.done
+}
+
+// Write commits batch and blocks until it is durable, folding it into the
+// same group commit as any other writer submitting concurrently through
+// Write or WriteAsync.
+func (sdb *ShardingDB) Write(batch *WriteBatch) error {
+	return <-sdb.WriteAsync(batch)
+}
+
+// runWriteGroupCommit is the single goroutine that owns WAL writes for
+// batches submitted through WriteAsync. Modeled on goleveldb's
+// db_write.go: rather than every writer taking turns to fsync its own
+// batch, the first request to arrive opens a round, this goroutine drains
+// whatever else has queued up behind it, and it commits the whole group as
+// one WAL write. Writers themselves only enqueue and wait.
+func (sdb *ShardingDB) runWriteGroupCommit(c *y.Closer) {
+	defer c.Done()
+	for {
+		var first *writeRequest
+		select {
+		case first = <-sdb.writeCh:
+		case <-c.HasBeenClosed():
+			return
+		}
+		group := []*writeRequest{first}
+	drain:
+		for {
+			select {
+			case req := <-sdb.writeCh:
+				group = append(group, req)
+			default:
+				break drain
+			}
+		}
+		err := sdb.commitWriteGroup(group)
+		for _, req := range group {
+			req.done <- err
+		}
+	}
+}
+
+// commitWriteGroup reserves one contiguous sequence range spanning every
+// batch in the group, appends them to the WAL as a single write, and only
+// then fans them out into the active memtable. Batches apply in arrival
+// order, so a later batch's write to a key also touched earlier in the
+// group wins - the same result a caller would see if the batches had
+// committed one at a time.
+//
+// The memtable fan-out shares one hint per column family across the whole
+// group instead of one per batch, so a run of batches with sorted or
+// disjoint key ranges still rides the fast splice path in
+// skiplist.PutWithHint even though they arrived from different writers.
+func (sdb *ShardingDB) commitWriteGroup(group []*writeRequest) error {
+	total := uint64(0)
+	for _, req := range group {
+		total += uint64(req.batch.Len())
+	}
+	seq := sdb.allocCommitTS(total)
+	batches := make([]*WriteBatch, len(group))
+	for i, req := range group {
+		req.batch.SetSeq(seq)
+		batches[i] = req.batch
+		seq += uint64(req.batch.Len())
+	}
+	// Each batch keeps its own (seq, count) header, so the batches must be
+	// length-prefixed when concatenated - otherwise decodeWriteBatchGroup
+	// would have no way to tell where one batch's entries end and the next
+	// batch's header begins, and WAL replay would misparse the second
+	// batch onward as soon as a group held more than one writer.
+	if err := sdb.wal.Write(encodeWriteBatchGroup(batches)); err != nil {
+		return errors.AddStack(err)
+	}
+	tbl := sdb.getWritableMemTable()
+	hints := make([]memtable.Hint, sdb.numCFs)
+	for _, req := range group {
+		req.batch.applyWithHints(tbl, hints)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package badger
+
+import (
+	"testing"
+
+	"github.com/pingcap/badger/y"
+)
+
+func TestWriteBatchGroupEncodeDecodeRoundTrip(t *testing.T) {
+	a := NewWriteBatch(1)
+	a.Put(0, y.KeyWithTs([]byte("a"), 0), y.ValueStruct{Value: []byte("1")})
+	a.SetSeq(10)
+
+	b := NewWriteBatch(1)
+	b.Put(0, y.KeyWithTs([]byte("b"), 0), y.ValueStruct{Value: []byte("2")})
+	b.Delete(0, y.KeyWithTs([]byte("c"), 0))
+	b.SetSeq(11)
+
+	group := encodeWriteBatchGroup([]*WriteBatch{a, b})
+	decoded, err := decodeWriteBatchGroup(1, group)
+	if err != nil {
+		t.Fatalf("decodeWriteBatchGroup: %v", err)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("got %d batches, want 2", len(decoded))
+	}
+	if decoded[0].seq != 10 || len(decoded[0].entries) != 1 {
+		t.Fatalf("batch 0 mismatch: %+v", decoded[0])
+	}
+	if decoded[1].seq != 11 || len(decoded[1].entries) != 2 {
+		t.Fatalf("batch 1 mismatch: %+v", decoded[1])
+	}
+	if string(decoded[0].entries[0].val.Value) != "1" {
+		t.Fatalf("batch 0 entry 0 value = %q, want %q", decoded[0].entries[0].val.Value, "1")
+	}
+}
+
+// TestWriteBatchGroupEncodeDecodeRoundTrip_ManyBatches guards against
+// regressing to the bug this test series was added to fix: naively
+// concatenating each batch's Encode() output with no length prefix makes it
+// impossible to tell where one batch's entries end and the next batch's
+// header begins as soon as a group holds more than one batch.
+func TestWriteBatchGroupEncodeDecodeRoundTrip_ManyBatches(t *testing.T) {
+	var group []*WriteBatch
+	for i := 0; i < 5; i++ {
+		wb := NewWriteBatch(1)
+		wb.Put(0, y.KeyWithTs([]byte{byte('a' + i)}, 0), y.ValueStruct{Value: []byte{byte(i)}})
+		wb.SetSeq(uint64(i))
+		group = append(group, wb)
+	}
+	decoded, err := decodeWriteBatchGroup(1, encodeWriteBatchGroup(group))
+	if err != nil {
+		t.Fatalf("decodeWriteBatchGroup: %v", err)
+	}
+	if len(decoded) != len(group) {
+		t.Fatalf("got %d batches, want %d", len(decoded), len(group))
+	}
+	for i, wb := range decoded {
+		if wb.seq != uint64(i) || len(wb.entries) != 1 {
+			t.Fatalf("batch %d mismatch: %+v", i, wb)
+		}
+	}
+}